@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+)
+
+// manifestNamespace返回清单里声明的命名空间,未声明时回退到"default",与applyToCluster的默认行为保持一致。
+func manifestNamespace(obj *unstructured.Unstructured) string {
+	if ns := obj.GetNamespace(); ns != "" {
+		return ns
+	}
+	return "default"
+}
+
+// fieldManager用于server-side apply,标记出这些字段由k8s-backend管理。
+const fieldManager = "k8s-backend"
+
+// decodeManifest把/apply的请求体(JSON或YAML)解析成unstructured对象;/propagate的清单随请求JSON一起绑定,不经过这里。
+func decodeManifest(c *gin.Context) (*unstructured.Unstructured, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{}
+	contentType := c.Request.Header.Get("Content-Type")
+	switch contentType {
+	case binding.MIMEJSON:
+		if _, _, err := unstructured.UnstructuredJSONScheme.Decode(body, nil, obj); err != nil {
+			return nil, err
+		}
+	case binding.MIMEYAML:
+		jsonBody, err := yaml.ToJSON(body)
+		if err != nil {
+			return nil, err
+		}
+		if _, _, err := unstructured.UnstructuredJSONScheme.Decode(jsonBody, nil, obj); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errUnsupportedContentType
+	}
+
+	return obj, nil
+}
+
+var errUnsupportedContentType = fmt.Errorf("unsupported content type")
+
+/**
+ * 接受任意YAML/JSON清单,解析出GVK后通过discovery/RESTMapper分发到对应的动态客户端,
+ * 以server-side apply(field manager为"k8s-backend")创建或更新资源(POST /apply)。
+ */
+func applyResource(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	obj, err := decodeManifest(c)
+	if err != nil {
+		if err == errUnsupportedContentType {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, manifestNamespace(obj)) {
+		return
+	}
+
+	applied, err := applyToCluster(entry, user, obj)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, applied)
+}
+
+// applyToCluster把一个unstructured对象server-side apply到指定集群,供/apply和/propagate共用。
+func applyToCluster(entry *ClusterEntry, user *User, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := entry.RESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := manifestNamespace(obj)
+	obj.SetNamespace(namespace)
+
+	dynamicClient, err := dynamic.NewForConfig(restConfigForUser(entry, user))
+	if err != nil {
+		return nil, err
+	}
+
+	var resourceClient dynamic.ResourceInterface
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resourceClient = dynamicClient.Resource(mapping.Resource).Namespace(namespace)
+	} else {
+		resourceClient = dynamicClient.Resource(mapping.Resource)
+	}
+
+	patchBody, err := runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return resourceClient.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, patchBody, metav1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        boolPtr(true),
+	})
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	authzv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const authJWTSecretEnv = "AUTH_JWT_SECRET"
+
+const contextUserKey = "user"
+
+// User是鉴权通过之后附加到请求上下文里的身份信息,
+// Groups对应Kubernetes RBAC的用户组,AllowedNamespaces是后端自己做的命名空间白名单。
+type User struct {
+	Name              string   `json:"name"`
+	Groups            []string `json:"groups"`
+	AllowedNamespaces []string `json:"allowedNamespaces"`
+}
+
+type userClaims struct {
+	jwt.RegisteredClaims
+	Groups            []string `json:"groups"`
+	AllowedNamespaces []string `json:"allowedNamespaces"`
+}
+
+func jwtSecret() ([]byte, error) {
+	secret := os.Getenv(authJWTSecretEnv)
+	if secret == "" {
+		return nil, fmt.Errorf("%s is not configured", authJWTSecretEnv)
+	}
+	return []byte(secret), nil
+}
+
+// generateToken为通过登录校验的用户签发一个有效期24小时的JWT。
+func generateToken(user User) (string, error) {
+	secret, err := jwtSecret()
+	if err != nil {
+		return "", err
+	}
+
+	claims := userClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Name,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+		},
+		Groups:            user.Groups,
+		AllowedNamespaces: user.AllowedNamespaces,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+func parseToken(tokenString string) (*User, error) {
+	secret, err := jwtSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &userClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return secret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	return &User{
+		Name:              claims.Subject,
+		Groups:            claims.Groups,
+		AllowedNamespaces: claims.AllowedNamespaces,
+	}, nil
+}
+
+// authMiddleware从Authorization: Bearer <token>里解析JWT,把User附加到上下文,
+// 解析失败直接返回401,后续的handler因此都能假定c里一定有一个合法的User。
+//
+// 浏览器发起的WebSocket升级请求没法自定义请求头,因此同时接受?access_token=<jwt>
+// 这个query参数作为token来源;exec/portforward/logs的前端(xterm.js等)走的就是这条路径。
+func authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		user, err := parseToken(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(contextUserKey, user)
+		c.Next()
+	}
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer "), true
+	}
+	if token := c.Query("access_token"); token != "" {
+		return token, true
+	}
+	return "", false
+}
+
+func userFromContext(c *gin.Context) *User {
+	value, exists := c.Get(contextUserKey)
+	if !exists {
+		return nil
+	}
+	user, ok := value.(*User)
+	if !ok {
+		return nil
+	}
+	return user
+}
+
+// isNamespaceAllowed用命名空间白名单取代了原先写死的kube-system/kube-public/...跳过列表,"*"表示允许所有命名空间。
+func isNamespaceAllowed(user *User, namespace string) bool {
+	if user == nil {
+		return false
+	}
+	for _, allowed := range user.AllowedNamespaces {
+		if allowed == "*" || allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// requireNamespaceAccess校验user是否被允许访问namespace,不允许时直接写回403并返回false,
+// 调用方应在拿到目标namespace后、发起任何clientset调用前调这个函数。
+func requireNamespaceAccess(c *gin.Context, user *User, namespace string) bool {
+	if isNamespaceAllowed(user, namespace) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("namespace %q is not in the allowlist for this user", namespace)})
+	return false
+}
+
+// requirePrivileged校验user是否属于admin组,不是时直接写回403并返回false,
+// 集群注册表的增删改查等跨租户操作必须先过这一关,不能只靠命名空间白名单。
+func requirePrivileged(c *gin.Context, user *User) bool {
+	if isPrivileged(user) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "admin privileges are required for this operation"})
+	return false
+}
+
+func isPrivileged(user *User) bool {
+	if user == nil {
+		return false
+	}
+	for _, group := range user.Groups {
+		if group == "admin" {
+			return true
+		}
+	}
+	return false
+}
+
+// restConfigForUser给特权用户返回一个携带Impersonate身份的rest.Config副本,让apiserver自己的RBAC生效;
+// 非特权用户继续使用集群注册时的原始Config(依赖后端自己的命名空间白名单做限制)。
+func restConfigForUser(entry *ClusterEntry, user *User) *rest.Config {
+	if !isPrivileged(user) {
+		return entry.Config
+	}
+
+	impersonatedConfig := rest.CopyConfig(entry.Config)
+	impersonatedConfig.Impersonate = rest.ImpersonationConfig{
+		UserName: user.Name,
+		Groups:   user.Groups,
+	}
+	return impersonatedConfig
+}
+
+// clientsetForUser给特权用户返回一个携带Impersonate身份的clientset,让apiserver自己的RBAC生效,
+// 而不是完全依赖后端的命名空间白名单;非特权用户继续使用集群注册时的clientset。
+func clientsetForUser(entry *ClusterEntry, user *User) (*kubernetes.Clientset, error) {
+	if !isPrivileged(user) {
+		return entry.Clientset, nil
+	}
+	return kubernetes.NewForConfig(restConfigForUser(entry, user))
+}
+
+// respondForbidden把apiserver返回的Forbidden错误翻译成带SSAR原因的HTTP 403,
+// 函数内部先用SelfSubjectAccessReview问一下apiserver"为什么",再把Reason透传给前端。
+func respondForbidden(c *gin.Context, entry *ClusterEntry, user *User, verb, resource, namespace string) {
+	reason := "forbidden by Kubernetes RBAC"
+	review := &authzv1.SelfSubjectAccessReview{
+		Spec: authzv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authzv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Resource:  resource,
+			},
+		},
+	}
+
+	clientset, err := clientsetForUser(entry, user)
+	if err == nil {
+		if result, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), review, metav1.CreateOptions{}); err == nil {
+			if result.Status.Reason != "" {
+				reason = result.Status.Reason
+			}
+		}
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": reason})
+}
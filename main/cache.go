@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ResourceCache 是一个按 "namespace/name" 做键的线程安全缓存，
+// 由 informer 的 AddFunc/UpdateFunc/DeleteFunc 回调维护，
+// 读取路径(getPods/getDeployments/...)直接从这里取数据，不再每次请求都访问 API Server。
+type ResourceCache struct {
+	items sync.Map // key: "namespace/name" -> value: runtime object
+}
+
+func cacheKey(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+func (c *ResourceCache) Add(namespace, name string, obj interface{}) {
+	c.items.Store(cacheKey(namespace, name), obj)
+}
+
+func (c *ResourceCache) Update(namespace, name string, obj interface{}) {
+	c.items.Store(cacheKey(namespace, name), obj)
+}
+
+func (c *ResourceCache) Delete(namespace, name string) {
+	c.items.Delete(cacheKey(namespace, name))
+}
+
+// Len 返回缓存中的对象总数,供/metrics端点上报informer缓存大小使用。
+func (c *ResourceCache) Len() int {
+	count := 0
+	c.items.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// List 返回缓存中的全部对象；namespace 为空字符串时返回所有命名空间的数据。
+func (c *ResourceCache) List(namespace string) []interface{} {
+	var result []interface{}
+	c.items.Range(func(key, value interface{}) bool {
+		if namespace == "" {
+			result = append(result, value)
+			return true
+		}
+		k := key.(string)
+		if len(k) > len(namespace) && k[:len(namespace)] == namespace && k[len(namespace)] == '/' {
+			result = append(result, value)
+		}
+		return true
+	})
+	return result
+}
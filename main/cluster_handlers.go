@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// resolveCluster按?cluster=查询参数(为空时落到defaultClusterName)解析出集群条目,
+// 所有资源类handler都先调用它来决定去哪个集群执行kube API调用。
+func resolveCluster(c *gin.Context) (*ClusterEntry, bool) {
+	entry, err := clusterManager.Get(c.Query("cluster"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return entry, true
+}
+
+type registerClusterRequest struct {
+	Name       string `json:"name" binding:"required"`
+	Kubeconfig string `json:"kubeconfig"` // base64编码的完整kubeconfig,优先使用
+	Server     string `json:"server"`     // 未提供kubeconfig时,用server+token+caData拼一个
+	Token      string `json:"token"`
+	CAData     string `json:"caData"` // base64编码的PEM证书
+	Insecure   bool   `json:"insecure"`
+}
+
+/**
+ * 注册一个新集群,接受base64编码的kubeconfig或server+token+caData三元组(POST /cluster)
+ */
+func registerCluster(c *gin.Context) {
+	if !requirePrivileged(c, userFromContext(c)) {
+		return
+	}
+
+	var req registerClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := clusterManager.Get(req.Name); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("cluster %q is already registered", req.Name)})
+		return
+	}
+
+	var kubeconfigBytes []byte
+	if req.Kubeconfig != "" {
+		decoded, err := base64.StdEncoding.DecodeString(req.Kubeconfig)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid base64 kubeconfig: " + err.Error()})
+			return
+		}
+		kubeconfigBytes = decoded
+	} else if req.Server != "" && req.Token != "" {
+		built, err := buildKubeconfigFromTuple(req.Name, req.Server, req.Token, req.CAData, req.Insecure)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		kubeconfigBytes = built
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "either kubeconfig or server+token must be provided"})
+		return
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := clusterManager.AddCluster(req.Name, config, kubeconfigBytes, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry.Status())
+}
+
+/**
+ * 列出所有已注册集群及其健康状态(GET /clusters)
+ */
+func listClusters(c *gin.Context) {
+	if !requirePrivileged(c, userFromContext(c)) {
+		return
+	}
+	c.JSON(http.StatusOK, clusterManager.List())
+}
+
+/**
+ * 注销一个集群,停止它的informer并从持久化注册表里删除(DELETE /cluster/:name)
+ */
+func deleteCluster(c *gin.Context) {
+	if !requirePrivileged(c, userFromContext(c)) {
+		return
+	}
+
+	name := c.Param("name")
+	if name == defaultClusterName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the default cluster cannot be removed"})
+		return
+	}
+	if err := clusterManager.Remove(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+/**
+ * 查询单个集群的健康状态(GET /cluster/:name/status)
+ */
+func getClusterStatus(c *gin.Context) {
+	if !requirePrivileged(c, userFromContext(c)) {
+		return
+	}
+
+	entry, err := clusterManager.Get(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry.Status())
+}
+
+func buildKubeconfigFromTuple(name, server, token, caDataB64 string, insecure bool) ([]byte, error) {
+	var caData []byte
+	if caDataB64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(caDataB64)
+		if err != nil {
+			return nil, err
+		}
+		caData = decoded
+	}
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters[name] = &clientcmdapi.Cluster{
+		Server:                   server,
+		CertificateAuthorityData: caData,
+		InsecureSkipTLSVerify:    insecure,
+	}
+	config.AuthInfos[name] = &clientcmdapi.AuthInfo{
+		Token: token,
+	}
+	config.Contexts[name] = &clientcmdapi.Context{
+		Cluster:  name,
+		AuthInfo: name,
+	}
+	config.CurrentContext = name
+
+	return clientcmd.Write(*config)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	memory "k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// defaultClusterName是进程启动时从本地kubeconfig/集群内配置加载的那个集群,
+// 所有没有带?cluster=参数的请求都落到它上面,保持与单集群版本的行为兼容。
+const defaultClusterName = "default"
+
+const clusterHealthCheckInterval = 30 * time.Second
+
+// ClusterStatus是/cluster/:name/status返回的健康快照。
+type ClusterStatus struct {
+	Name      string    `json:"name"`
+	Ready     bool      `json:"ready"`
+	Version   string    `json:"version,omitempty"`
+	NodeCount int       `json:"nodeCount"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// ClusterEntry持有单个集群的全部客户端、informer缓存和健康状态,
+// 取代了原先的全局clientset/metricsClientset/restConfig单例。
+type ClusterEntry struct {
+	Name             string
+	Config           *rest.Config
+	Clientset        *kubernetes.Clientset
+	MetricsClientset *versioned.Clientset
+	DynamicClient    dynamic.Interface
+	RESTMapper       *restmapper.DeferredDiscoveryRESTMapper
+
+	podCache        *ResourceCache
+	deploymentCache *ResourceCache
+	serviceCache    *ResourceCache
+	configMapCache  *ResourceCache
+	watchHub        *WatchHub
+	informerFactory informers.SharedInformerFactory
+	stopCh          chan struct{}
+
+	statusMu sync.RWMutex
+	status   ClusterStatus
+}
+
+func (e *ClusterEntry) Status() ClusterStatus {
+	e.statusMu.RLock()
+	defer e.statusMu.RUnlock()
+	return e.status
+}
+
+func (e *ClusterEntry) setStatus(status ClusterStatus) {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	e.status = status
+}
+
+// ClusterManager按名字索引已注册的集群,是多集群特性的核心注册表。
+type ClusterManager struct {
+	mu          sync.RWMutex
+	clusters    map[string]*ClusterEntry
+	registryDir string
+}
+
+func NewClusterManager(registryDir string) *ClusterManager {
+	return &ClusterManager{
+		clusters:    make(map[string]*ClusterEntry),
+		registryDir: registryDir,
+	}
+}
+
+// AddCluster为一个kubeconfig构建客户端集合、启动informer并注册健康检查,
+// persist为true时把kubeconfig加密后写入registryDir,重启后可以通过LoadPersisted恢复。
+func (m *ClusterManager) AddCluster(name string, config *rest.Config, kubeconfigBytes []byte, persist bool) (*ClusterEntry, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	metricsClientset, err := versioned.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics clientset: %w", err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	entry := &ClusterEntry{
+		Name:             name,
+		Config:           config,
+		Clientset:        clientset,
+		MetricsClientset: metricsClientset,
+		DynamicClient:    dynamicClient,
+		RESTMapper:       restMapper,
+		podCache:         &ResourceCache{},
+		deploymentCache:  &ResourceCache{},
+		serviceCache:     &ResourceCache{},
+		configMapCache:   &ResourceCache{},
+		watchHub:         NewWatchHub(),
+		stopCh:           make(chan struct{}),
+	}
+
+	if err := entry.startInformers(); err != nil {
+		return nil, fmt.Errorf("failed to start informers: %w", err)
+	}
+
+	m.mu.Lock()
+	if existing, ok := m.clusters[name]; ok {
+		existing.stop()
+	}
+	m.clusters[name] = entry
+	m.mu.Unlock()
+
+	go entry.healthCheckLoop()
+
+	if persist {
+		if err := m.persist(name, kubeconfigBytes); err != nil {
+			return entry, fmt.Errorf("cluster registered but failed to persist: %w", err)
+		}
+	}
+
+	return entry, nil
+}
+
+// Get按名字查找集群,空字符串解析为默认集群,保持旧版本(单集群)的行为不变。
+func (m *ClusterManager) Get(name string) (*ClusterEntry, error) {
+	if name == "" {
+		name = defaultClusterName
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, ok := m.clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q is not registered", name)
+	}
+	return entry, nil
+}
+
+func (m *ClusterManager) List() []ClusterStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]ClusterStatus, 0, len(m.clusters))
+	for _, entry := range m.clusters {
+		statuses = append(statuses, entry.Status())
+	}
+	return statuses
+}
+
+// Remove停掉集群的informer并从注册表和磁盘上移除它。
+func (m *ClusterManager) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.clusters[name]
+	if !ok {
+		return fmt.Errorf("cluster %q is not registered", name)
+	}
+	entry.stop()
+	delete(m.clusters, name)
+
+	if m.registryDir != "" {
+		_ = os.Remove(filepath.Join(m.registryDir, name+".enc"))
+	}
+	return nil
+}
+
+func (m *ClusterManager) persist(name string, kubeconfigBytes []byte) error {
+	if m.registryDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.registryDir, 0700); err != nil {
+		return err
+	}
+	encrypted, err := encryptKubeconfig(kubeconfigBytes)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.registryDir, name+".enc"), encrypted, 0600)
+}
+
+// LoadPersisted在启动时读取registryDir里保存的每一份加密kubeconfig并重新注册对应集群。
+func (m *ClusterManager) LoadPersisted() error {
+	if m.registryDir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(m.registryDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, fileEntry := range entries {
+		if fileEntry.IsDir() {
+			continue
+		}
+		name := fileEntry.Name()
+		ext := filepath.Ext(name)
+		if ext != ".enc" {
+			continue
+		}
+		clusterName := name[:len(name)-len(ext)]
+
+		encrypted, err := os.ReadFile(filepath.Join(m.registryDir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read persisted cluster %q: %w", clusterName, err)
+		}
+		kubeconfigBytes, err := decryptKubeconfig(encrypted)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt persisted cluster %q: %w", clusterName, err)
+		}
+		config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigBytes)
+		if err != nil {
+			return fmt.Errorf("failed to build rest.Config for persisted cluster %q: %w", clusterName, err)
+		}
+		if _, err := m.AddCluster(clusterName, config, kubeconfigBytes, false); err != nil {
+			return fmt.Errorf("failed to restore persisted cluster %q: %w", clusterName, err)
+		}
+	}
+	return nil
+}
+
+func (e *ClusterEntry) stop() {
+	close(e.stopCh)
+	if e.informerFactory != nil {
+		e.informerFactory.Shutdown()
+	}
+	e.watchHub.Close()
+}
+
+func (e *ClusterEntry) healthCheckLoop() {
+	ticker := time.NewTicker(clusterHealthCheckInterval)
+	defer ticker.Stop()
+
+	e.checkHealth()
+	for {
+		select {
+		case <-ticker.C:
+			e.checkHealth()
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+func (e *ClusterEntry) checkHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	version, err := e.Clientset.Discovery().ServerVersion()
+	if err != nil {
+		e.setStatus(ClusterStatus{Name: e.Name, Ready: false, Error: err.Error(), CheckedAt: time.Now()})
+		return
+	}
+
+	nodes, err := e.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		e.setStatus(ClusterStatus{Name: e.Name, Ready: false, Version: version.GitVersion, Error: err.Error(), CheckedAt: time.Now()})
+		return
+	}
+
+	e.setStatus(ClusterStatus{
+		Name:      e.Name,
+		Ready:     true,
+		Version:   version.GitVersion,
+		NodeCount: len(nodes.Items),
+		CheckedAt: time.Now(),
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+)
+
+// clusterRegistryKeyEnv持有对称加密密钥的环境变量,注册表里的kubeconfig落盘前都用它加密。
+const clusterRegistryKeyEnv = "CLUSTER_REGISTRY_KEY"
+
+func clusterRegistryCipherKey() ([]byte, error) {
+	raw := os.Getenv(clusterRegistryKeyEnv)
+	if raw == "" {
+		return nil, errors.New(clusterRegistryKeyEnv + " is not set, cannot persist cluster registry")
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return sum[:], nil
+}
+
+// encryptKubeconfig用AES-GCM加密kubeconfig内容,便于安全落盘。
+func encryptKubeconfig(plaintext []byte) ([]byte, error) {
+	key, err := clusterRegistryCipherKey()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decryptKubeconfig(ciphertext []byte) ([]byte, error) {
+	key, err := clusterRegistryCipherKey()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, data := ciphertext[:nonceSize], ciphertext[nonceSize:]
+
+	return gcm.Open(nil, nonce, data, nil)
+}
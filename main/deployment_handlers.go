@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// restartedAtAnnotation 与`kubectl rollout restart`使用的注解保持一致,
+// 通过修改它触发Deployment的滚动重启。
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+/**
+ * 根据JSON对Deployment做策略合并补丁(PUT /deployment/:namespace/:name)
+ */
+func updateDeployment(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedDeployment, err := clientset.AppsV1().Deployments(namespace).Patch(
+		context.TODO(), name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "patch", "deployments", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedDeployment)
+}
+
+/**
+ * 伸缩Deployment副本数(PATCH /deployment/:namespace/:name/scale)
+ */
+func scaleDeployment(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body struct {
+		Replicas int32 `json:"replicas"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scale, err := clientset.AppsV1().Deployments(namespace).GetScale(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "patch", "deployments/scale", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	scale.Spec.Replicas = body.Replicas
+
+	updatedScale, err := clientset.AppsV1().Deployments(namespace).UpdateScale(
+		context.TODO(), name, scale, metav1.UpdateOptions{})
+	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "patch", "deployments/scale", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedScale)
+}
+
+/**
+ * 触发Deployment滚动重启,效果等同于`kubectl rollout restart`(POST /deployment/:namespace/:name/restart)
+ */
+func restartDeployment(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	patch := fmt.Sprintf(
+		`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		restartedAtAnnotation, time.Now().Format(time.RFC3339))
+
+	updatedDeployment, err := clientset.AppsV1().Deployments(namespace).Patch(
+		context.TODO(), name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "patch", "deployments", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedDeployment)
+}
+
+/**
+ * 查询Deployment的滚动发布状态(GET /deployment/:namespace/:name/rollout-status)
+ */
+func getDeploymentRolloutStatus(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "get", "deployments", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	desiredReplicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desiredReplicas = *deployment.Spec.Replicas
+	}
+
+	observedCurrentGeneration := deployment.Status.ObservedGeneration == deployment.Generation
+	updatedReplicasMatch := deployment.Status.UpdatedReplicas == desiredReplicas
+	availableReplicasMatch := deployment.Status.AvailableReplicas == desiredReplicas
+	complete := observedCurrentGeneration && updatedReplicasMatch && availableReplicasMatch
+
+	c.JSON(http.StatusOK, gin.H{
+		"complete":           complete,
+		"desiredReplicas":    desiredReplicas,
+		"updatedReplicas":    deployment.Status.UpdatedReplicas,
+		"readyReplicas":      deployment.Status.ReadyReplicas,
+		"availableReplicas":  deployment.Status.AvailableReplicas,
+		"observedGeneration": deployment.Status.ObservedGeneration,
+		"generation":         deployment.Generation,
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	memory "k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const helmRepositoryConfigEnv = "HELM_REPOSITORY_CONFIG"
+
+// helmSettings复用Helm CLI自己的默认目录布局(仓库索引缓存、chart缓存等),
+// 只有HELM_REPOSITORY_CONFIG被显式设置时才覆盖repositories.yaml的位置。
+func helmSettings() *cli.EnvSettings {
+	settings := cli.New()
+	if path := os.Getenv(helmRepositoryConfigEnv); path != "" {
+		settings.RepositoryConfig = path
+	}
+	return settings
+}
+
+// helmRESTClientGetter把一个已注册集群的rest.Config适配成action.Configuration.Init需要的RESTClientGetter,
+// 这样Helm发起的每一次Kubernetes API调用都走同一个集群的凭据,不需要再单独管理一份kubeconfig。
+type helmRESTClientGetter struct {
+	entry *ClusterEntry
+	user  *User
+}
+
+func (g *helmRESTClientGetter) ToRESTConfig() (*rest.Config, error) {
+	return restConfigForUser(g.entry, g.user), nil
+}
+
+func (g *helmRESTClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfigForUser(g.entry, g.user))
+	if err != nil {
+		return nil, err
+	}
+	return memory.NewMemCacheClient(discoveryClient), nil
+}
+
+func (g *helmRESTClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	return g.entry.RESTMapper, nil
+}
+
+func (g *helmRESTClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return clientcmd.NewDefaultClientConfig(clientcmdapi.Config{}, &clientcmd.ConfigOverrides{})
+}
+
+// newHelmConfiguration为指定集群/命名空间构建一个Helm action.Configuration,
+// logf接收安装/升级过程中的进度日志,调用方可以把它转发到SSE流上。
+// user用于构建底层RESTClientGetter,特权用户会像其他handler一样走Impersonate,让apiserver自己的RBAC生效。
+func newHelmConfiguration(entry *ClusterEntry, user *User, namespace string, logf action.DebugLog) (*action.Configuration, error) {
+	cfg := new(action.Configuration)
+	getter := &helmRESTClientGetter{entry: entry, user: user}
+	if err := cfg.Init(getter, namespace, os.Getenv("HELM_DRIVER"), logf); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
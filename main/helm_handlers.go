@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// helmRolloutTimeout是install/upgrade等待资源就绪的超时时间,超时后Wait失败并通过SSE返回错误。
+const helmRolloutTimeout = 5 * time.Minute
+
+type addHelmRepoRequest struct {
+	Name string `json:"name" binding:"required"`
+	URL  string `json:"url" binding:"required"`
+}
+
+/**
+ * 添加一个Chart仓库并立即下载一次索引(POST /helm/repo)
+ */
+func addHelmRepo(c *gin.Context) {
+	var req addHelmRepoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := helmSettings()
+
+	file, err := repo.LoadFile(settings.RepositoryConfig)
+	if err != nil {
+		file = repo.NewFile()
+	}
+
+	entry := &repo.Entry{Name: req.Name, URL: req.URL}
+	chartRepo, err := repo.NewChartRepository(entry, getter.All(settings))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := chartRepo.DownloadIndexFile(); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to download repo index: %v", err)})
+		return
+	}
+
+	file.Update(entry)
+	if err := file.WriteFile(settings.RepositoryConfig, 0644); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+ * 列出本地已添加的全部Chart仓库(GET /helm/repos)
+ */
+func listHelmRepos(c *gin.Context) {
+	file, err := repo.LoadFile(helmSettings().RepositoryConfig)
+	if err != nil {
+		c.JSON(http.StatusOK, []*repo.Entry{})
+		return
+	}
+	c.JSON(http.StatusOK, file.Repositories)
+}
+
+type chartVersionInfo struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	AppVersion string `json:"appVersion"`
+}
+
+/**
+ * 列出指定仓库索引里的全部Chart及其版本(GET /helm/charts?repo=)
+ */
+func listHelmCharts(c *gin.Context) {
+	repoName := c.Query("repo")
+	if repoName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "repo is required"})
+		return
+	}
+
+	settings := helmSettings()
+	indexPath := filepath.Join(settings.RepositoryCache, helmpath.CacheIndexFile(repoName))
+	index, err := repo.LoadIndexFile(indexPath)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("index for repo %q not found, add it first", repoName)})
+		return
+	}
+
+	var charts []chartVersionInfo
+	for name, versions := range index.Entries {
+		for _, version := range versions {
+			charts = append(charts, chartVersionInfo{
+				Name:       name,
+				Version:    version.Version,
+				AppVersion: version.AppVersion,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, charts)
+}
+
+type installReleaseRequest struct {
+	Chart     string                 `json:"chart" binding:"required"`
+	Version   string                 `json:"version"`
+	Name      string                 `json:"name" binding:"required"`
+	Namespace string                 `json:"namespace" binding:"required"`
+	Values    map[string]interface{} `json:"values"`
+}
+
+/**
+ * 安装一个Release,通过SSE推送进度日志,直到install.Run返回(POST /helm/release)
+ */
+func installHelmRelease(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	var req installReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, req.Namespace) {
+		return
+	}
+
+	events := make(chan string, 16)
+	cfg, err := newHelmConfiguration(entry, user, req.Namespace, func(format string, v ...interface{}) {
+		events <- fmt.Sprintf(format, v...)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	install := action.NewInstall(cfg)
+	install.Namespace = req.Namespace
+	install.ReleaseName = req.Name
+	install.Version = req.Version
+	install.CreateNamespace = true
+	install.Wait = true
+	install.Timeout = helmRolloutTimeout
+
+	settings := helmSettings()
+	chartPath, err := install.ChartPathOptions.LocateChart(req.Chart, settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	go func() {
+		if _, err := install.Run(chart, req.Values); err != nil {
+			events <- "error: " + err.Error()
+		} else {
+			events <- "complete: release installed"
+		}
+		close(events)
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		msg, ok := <-events
+		if !ok {
+			return false
+		}
+		c.SSEvent("progress", msg)
+		return true
+	})
+}
+
+type upgradeReleaseRequest struct {
+	Chart   string                 `json:"chart" binding:"required"`
+	Version string                 `json:"version"`
+	Values  map[string]interface{} `json:"values"`
+}
+
+/**
+ * 升级一个已安装的Release,通过SSE推送进度日志(PUT /helm/release/:ns/:name)
+ */
+func upgradeHelmRelease(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Param("ns")
+	name := c.Param("name")
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+
+	var req upgradeReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events := make(chan string, 16)
+	cfg, err := newHelmConfiguration(entry, user, namespace, func(format string, v ...interface{}) {
+		events <- fmt.Sprintf(format, v...)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	upgrade := action.NewUpgrade(cfg)
+	upgrade.Namespace = namespace
+	upgrade.Version = req.Version
+	upgrade.Wait = true
+	upgrade.Timeout = helmRolloutTimeout
+
+	settings := helmSettings()
+	chartPath, err := upgrade.ChartPathOptions.LocateChart(req.Chart, settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	go func() {
+		if _, err := upgrade.Run(name, chart, req.Values); err != nil {
+			events <- "error: " + err.Error()
+		} else {
+			events <- "complete: release upgraded"
+		}
+		close(events)
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		msg, ok := <-events
+		if !ok {
+			return false
+		}
+		c.SSEvent("progress", msg)
+		return true
+	})
+}
+
+/**
+ * 卸载一个Release(DELETE /helm/release/:ns/:name)
+ */
+func uninstallHelmRelease(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Param("ns")
+	name := c.Param("name")
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+
+	cfg, err := newHelmConfiguration(entry, user, namespace, func(string, ...interface{}) {})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	uninstall := action.NewUninstall(cfg)
+	if _, err := uninstall.Run(name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+ * 列出已安装的Release,?namespace=为空时列出所有命名空间,
+ * 这只对命名空间白名单含"*"的用户开放,其他用户返回403(GET /helm/releases)
+ */
+func listHelmReleases(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Query("namespace")
+	user := userFromContext(c)
+	if namespace == "" {
+		if !isNamespaceAllowed(user, "*") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "namespace query parameter is required unless all namespaces are allowed for this user"})
+			return
+		}
+	} else if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+
+	cfg, err := newHelmConfiguration(entry, user, namespace, func(string, ...interface{}) {})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := action.NewList(cfg)
+	list.AllNamespaces = namespace == ""
+
+	releases, err := list.Run()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, releases)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+const informerResyncPeriod = 30 * time.Second
+
+// startInformers为这个集群创建共享informer工厂,为Pod/Deployment/Service/ConfigMap注册事件回调,
+// 回调负责同步更新本地缓存并把事件广播到entry.watchHub供SSE订阅者消费。
+// list接口(getPods等)之后直接读取这里填充的缓存,不再每次请求都打到API Server。
+func (e *ClusterEntry) startInformers() error {
+	e.informerFactory = informers.NewSharedInformerFactory(e.Clientset, informerResyncPeriod)
+
+	podInformer := e.informerFactory.Core().V1().Pods().Informer()
+	if _, err := podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			pod := obj.(*v1.Pod)
+			e.podCache.Add(pod.Namespace, pod.Name, pod)
+			e.watchHub.Publish("pods", WatchEvent{Type: "Added", Resource: "pods", Object: pod})
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			pod := newObj.(*v1.Pod)
+			e.podCache.Update(pod.Namespace, pod.Name, pod)
+			e.watchHub.Publish("pods", WatchEvent{Type: "Updated", Resource: "pods", Object: pod})
+		},
+		DeleteFunc: func(obj interface{}) {
+			pod := toPod(obj)
+			e.podCache.Delete(pod.Namespace, pod.Name)
+			e.watchHub.Publish("pods", WatchEvent{Type: "Deleted", Resource: "pods", Object: pod})
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to register pod informer handler: %w", err)
+	}
+
+	deploymentInformer := e.informerFactory.Apps().V1().Deployments().Informer()
+	if _, err := deploymentInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			d := obj.(*appsv1.Deployment)
+			e.deploymentCache.Add(d.Namespace, d.Name, d)
+			e.watchHub.Publish("deployments", WatchEvent{Type: "Added", Resource: "deployments", Object: d})
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			d := newObj.(*appsv1.Deployment)
+			e.deploymentCache.Update(d.Namespace, d.Name, d)
+			e.watchHub.Publish("deployments", WatchEvent{Type: "Updated", Resource: "deployments", Object: d})
+		},
+		DeleteFunc: func(obj interface{}) {
+			d := toDeployment(obj)
+			e.deploymentCache.Delete(d.Namespace, d.Name)
+			e.watchHub.Publish("deployments", WatchEvent{Type: "Deleted", Resource: "deployments", Object: d})
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to register deployment informer handler: %w", err)
+	}
+
+	serviceInformer := e.informerFactory.Core().V1().Services().Informer()
+	if _, err := serviceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			s := obj.(*v1.Service)
+			e.serviceCache.Add(s.Namespace, s.Name, s)
+			e.watchHub.Publish("services", WatchEvent{Type: "Added", Resource: "services", Object: s})
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			s := newObj.(*v1.Service)
+			e.serviceCache.Update(s.Namespace, s.Name, s)
+			e.watchHub.Publish("services", WatchEvent{Type: "Updated", Resource: "services", Object: s})
+		},
+		DeleteFunc: func(obj interface{}) {
+			s := toService(obj)
+			e.serviceCache.Delete(s.Namespace, s.Name)
+			e.watchHub.Publish("services", WatchEvent{Type: "Deleted", Resource: "services", Object: s})
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to register service informer handler: %w", err)
+	}
+
+	configMapInformer := e.informerFactory.Core().V1().ConfigMaps().Informer()
+	if _, err := configMapInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			cm := obj.(*v1.ConfigMap)
+			e.configMapCache.Add(cm.Namespace, cm.Name, cm)
+			e.watchHub.Publish("configmaps", WatchEvent{Type: "Added", Resource: "configmaps", Object: cm})
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			cm := newObj.(*v1.ConfigMap)
+			e.configMapCache.Update(cm.Namespace, cm.Name, cm)
+			e.watchHub.Publish("configmaps", WatchEvent{Type: "Updated", Resource: "configmaps", Object: cm})
+		},
+		DeleteFunc: func(obj interface{}) {
+			cm := toConfigMap(obj)
+			e.configMapCache.Delete(cm.Namespace, cm.Name)
+			e.watchHub.Publish("configmaps", WatchEvent{Type: "Deleted", Resource: "configmaps", Object: cm})
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to register configmap informer handler: %w", err)
+	}
+
+	e.informerFactory.Start(e.stopCh)
+	e.informerFactory.WaitForCacheSync(e.stopCh)
+
+	return nil
+}
+
+// DeleteFunc在资源被删除时可能收到cache.DeletedFinalStateUnknown,这几个helper负责解包。
+func toPod(obj interface{}) *v1.Pod {
+	if pod, ok := obj.(*v1.Pod); ok {
+		return pod
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		if pod, ok := tombstone.Obj.(*v1.Pod); ok {
+			return pod
+		}
+	}
+	return &v1.Pod{}
+}
+
+func toDeployment(obj interface{}) *appsv1.Deployment {
+	if d, ok := obj.(*appsv1.Deployment); ok {
+		return d
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		if d, ok := tombstone.Obj.(*appsv1.Deployment); ok {
+			return d
+		}
+	}
+	return &appsv1.Deployment{}
+}
+
+func toService(obj interface{}) *v1.Service {
+	if s, ok := obj.(*v1.Service); ok {
+		return s
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		if s, ok := tombstone.Obj.(*v1.Service); ok {
+			return s
+		}
+	}
+	return &v1.Service{}
+}
+
+func toConfigMap(obj interface{}) *v1.ConfigMap {
+	if cm, ok := obj.(*v1.ConfigMap); ok {
+		return cm
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		if cm, ok := tombstone.Obj.(*v1.ConfigMap); ok {
+			return cm
+		}
+	}
+	return &v1.ConfigMap{}
+}
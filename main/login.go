@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	authAdminUserEnv     = "AUTH_ADMIN_USER"
+	authAdminPasswordEnv = "AUTH_ADMIN_PASSWORD"
+)
+
+type loginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type loginResponse struct {
+	Token string `json:"token"`
+}
+
+// login校验登录凭据并签发JWT(POST /login)。当前只支持从环境变量启动的管理员账号,
+// 拿到的是一个拥有admin组、可以访问所有命名空间的特权身份;后续可以在这里接入OIDC等其它provider。
+func login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminUser := os.Getenv(authAdminUserEnv)
+	adminPassword := os.Getenv(authAdminPasswordEnv)
+	if adminUser == "" || adminPassword == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "bootstrap admin is not configured"})
+		return
+	}
+
+	if req.Username != adminUser || req.Password != adminPassword {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	token, err := generateToken(User{
+		Name:              req.Username,
+		Groups:            []string{"admin"},
+		AllowedNamespaces: []string{"*"},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse{Token: token})
+}
@@ -3,95 +3,181 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/metrics/pkg/client/clientset/versioned"
-	"log"
-	"net/http"
 )
 
-var clientset *kubernetes.Clientset
-var metricsClientset *versioned.Clientset
+// clusterManager持有所有已注册集群的客户端/缓存/informer,取代了原先的全局clientset单例。
+var clusterManager *ClusterManager
 
 func main() {
-	// 加载Kubeconfig文件
+	// 加载本地/集群内的Kubeconfig文件,注册为defaultClusterName集群
 	kubeconfig := clientcmd.RecommendedHomeFile
+	kubeconfigBytes, err := os.ReadFile(kubeconfig)
+	if err != nil {
+		// 本地没有kubeconfig时清空路径,BuildConfigFromFlags会回退到集群内(ServiceAccount)配置
+		log.Printf("Failed to read kubeconfig, falling back to in-cluster config: %v", err)
+		kubeconfig = ""
+		kubeconfigBytes = nil
+	}
 	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
 		log.Fatalf("Failed to load kubeconfig: %v", err)
 	}
 
-	// 创建Kubernetes客户端
-	clientset, err = kubernetes.NewForConfig(config)
-	if err != nil {
-		log.Fatalf("Failed to create Kubernetes client: %v", err)
+	registryDir := os.Getenv("CLUSTER_REGISTRY_DIR")
+	if registryDir == "" {
+		registryDir = "./cluster-registry"
 	}
+	clusterManager = NewClusterManager(registryDir)
 
-	// 创建Metrics客户端
-	metricsClientset, err = versioned.NewForConfig(config)
-	if err != nil {
-		log.Fatalf("Failed to create Metrics client: %v", err)
+	if _, err := clusterManager.AddCluster(defaultClusterName, config, kubeconfigBytes, false); err != nil {
+		log.Fatalf("Failed to register default cluster: %v", err)
+	}
+
+	// 恢复之前持久化过的其它集群(加密保存在registryDir)
+	if err := clusterManager.LoadPersisted(); err != nil {
+		log.Printf("Failed to load persisted cluster registry: %v", err)
+	}
+
+	// 配置Prometheus查询客户端(PROMETHEUS_URL未设置时/metrics/node和/metrics/pod会返回501)
+	if err := initPrometheusClient(); err != nil {
+		log.Fatalf("Failed to init prometheus client: %v", err)
 	}
 
 	r := gin.Default()
 	r.Use(cors.Default())
+	r.Use(metricsMiddleware())
+
+	r.POST("/login", login) // 登录并签发JWT
+
+	r.Use(authMiddleware()) // 以下路由都要求带JWT(Authorization: Bearer <token>或?access_token=<token>)
 
 	r.GET("/cluster-info", getClusterInfo) // 获取集群信息
 	r.GET("/cluster-load", getClusterLoad) // 获取集群负载信息
 
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))      // k8s-backend自身的Prometheus指标
+	r.GET("/metrics/node/:name", getNodeRangeMetrics)     // 节点的历史CPU/内存用量
+	r.GET("/metrics/pod/:ns/:name", getPodRangeMetrics)   // Pod按容器拆分的历史CPU/内存用量
+
+	r.POST("/cluster", registerCluster)             // 注册一个新集群
+	r.GET("/clusters", listClusters)                // 列出所有已注册集群
+	r.DELETE("/cluster/:name", deleteCluster)       // 注销一个集群
+	r.GET("/cluster/:name/status", getClusterStatus) // 查询集群健康状态
+	r.POST("/propagate", propagateWorkload)         // 把一个workload下发到多个集群
+
 	r.POST("/pod", createPod)                    // 创建Pod
 	r.DELETE("/pod/:namespace/:name", deletePod) // 删除Pod
-	r.GET("/pods", getPods)                      // 获取所有Pod
+	r.GET("/pods", getPods)                      // 获取所有Pod(来自本地缓存)
+
+	r.GET("/pod/:namespace/:name/logs", streamPodLogs)          // 流式获取容器日志
+	r.GET("/pod/:namespace/:name/exec", execPod)                // websocket交互式shell
+	r.GET("/pod/:namespace/:name/portforward", portForwardPod)  // 端口转发(在后端本机localhost监听,websocket只回传状态输出)
 
 	r.POST("/deployment", createDeployment)                    // 创建Deployment
 	r.DELETE("/deployment/:namespace/:name", deleteDeployment) // 删除Deployment
-	r.GET("/deployments", getDeployments)                      // 获取所有Deployment
+	r.GET("/deployments", getDeployments)                      // 获取所有Deployment(来自本地缓存)
+	r.PUT("/deployment/:namespace/:name", updateDeployment)                          // 策略合并补丁更新Deployment
+	r.PATCH("/deployment/:namespace/:name/scale", scaleDeployment)                   // 伸缩Deployment副本数
+	r.POST("/deployment/:namespace/:name/restart", restartDeployment)                // 滚动重启Deployment
+	r.GET("/deployment/:namespace/:name/rollout-status", getDeploymentRolloutStatus) // 查询滚动发布状态
+
+	r.POST("/apply", applyResource) // 按GVK分发的通用server-side apply端点
 
 	r.POST("/service", createService)                    // 创建Service
 	r.DELETE("/service/:namespace/:name", deleteService) // 删除Service
-	r.GET("/services", getServices)                      // 获取所有Service的API
+	r.GET("/services", getServices)                      // 获取所有Service(来自本地缓存)
 
 	r.POST("/configmap", createConfigMap)                    // 创建ConfigMap
 	r.DELETE("/configmap/:namespace/:name", deleteConfigMap) // 删除ConfigMap
-	r.GET("/configmaps", getConfigMaps)                      // 获取所有ConfigMap
+	r.GET("/configmaps", getConfigMaps)                      // 获取所有ConfigMap(来自本地缓存)
 
-	err = r.Run(":8792")
-	if err != nil {
-		return
+	r.GET("/watch/:resource", watchResource) // SSE推送指定资源的Added/Updated/Deleted事件
+
+	r.POST("/helm/repo", addHelmRepo)                     // 添加Chart仓库
+	r.GET("/helm/repos", listHelmRepos)                   // 列出已添加的Chart仓库
+	r.GET("/helm/charts", listHelmCharts)                 // 列出指定仓库下的Chart及版本
+	r.POST("/helm/release", installHelmRelease)           // 安装Release,SSE推送进度
+	r.PUT("/helm/release/:ns/:name", upgradeHelmRelease)  // 升级Release,SSE推送进度
+	r.DELETE("/helm/release/:ns/:name", uninstallHelmRelease) // 卸载Release
+	r.GET("/helm/releases", listHelmReleases)             // 列出已安装的Release
+
+	srv := &http.Server{
+		Addr:    ":8792",
+		Handler: r,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to run server: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down server...")
+	for _, status := range clusterManager.List() {
+		if entry, err := clusterManager.Get(status.Name); err == nil {
+			entry.stop()
+		}
 	}
-}
 
-// TODO 在进行操作的时候, namespace不应该访问到系统的namespace
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
+	}
+}
 
 /**
- * 获取集群信息
+ * 获取集群信息(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func getClusterInfo(c *gin.Context) {
-	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	recordClientsetCall("nodes", "list")
+	nodes, err := entry.Clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	pods, err := clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	recordClientsetCall("pods", "list")
+	pods, err := entry.Clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	services, err := clientset.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{})
+	recordClientsetCall("services", "list")
+	services, err := entry.Clientset.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	deployments, err := clientset.AppsV1().Deployments("").List(context.TODO(), metav1.ListOptions{})
+	recordClientsetCall("deployments", "list")
+	deployments, err := entry.Clientset.AppsV1().Deployments("").List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -159,16 +245,23 @@ func getClusterInfo(c *gin.Context) {
 }
 
 /**
- * 获取集群负载信息
+ * 获取集群负载信息(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func getClusterLoad(c *gin.Context) {
-	nodeMetrics, err := metricsClientset.MetricsV1beta1().NodeMetricses().List(context.TODO(), metav1.ListOptions{})
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	recordClientsetCall("nodemetrics", "list")
+	nodeMetrics, err := entry.MetricsClientset.MetricsV1beta1().NodeMetricses().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	podMetrics, err := metricsClientset.MetricsV1beta1().PodMetricses("").List(context.TODO(), metav1.ListOptions{})
+	recordClientsetCall("podmetrics", "list")
+	podMetrics, err := entry.MetricsClientset.MetricsV1beta1().PodMetricses("").List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -219,22 +312,15 @@ func getClusterLoad(c *gin.Context) {
 }
 
 /**
- * 根据JSON创建Pod
+ * 根据JSON创建Pod(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func createPod(c *gin.Context) {
-	var pod v1.Pod
-
-	//if err := c.ShouldBindJSON(&pod); err != nil {
-	//	if err := c.ShouldBindYAML(&pod); err != nil {
-	//		if err := c.ShouldBindBodyWithJSON(&pod); err != nil {
-	//			if err := c.ShouldBindBodyWithYAML(&pod); err != nil {
-	//				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	//				return
-	//			}
-	//		}
-	//	}
-	//}
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
 
+	var pod v1.Pod
 	contentType := c.Request.Header.Get("Content-Type")
 	switch contentType {
 	case "application/json":
@@ -256,8 +342,23 @@ func createPod(c *gin.Context) {
 		pod.Namespace = "default"
 	}
 
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, pod.Namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordClientsetCall("pods", "create")
 	createdPod, err := clientset.CoreV1().Pods(pod.Namespace).Create(context.TODO(), &pod, metav1.CreateOptions{})
 	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "create", "pods", pod.Namespace)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -266,28 +367,46 @@ func createPod(c *gin.Context) {
 }
 
 /**
- * 根据Namespace和Pod名称删除Pod
+ * 根据Namespace和Pod名称删除Pod(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func deletePod(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	err := clientset.CoreV1().Pods(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	recordClientsetCall("pods", "delete")
+	if err := clientset.CoreV1().Pods(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "delete", "pods", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
 
 /**
- * 获取所有Pod
+ * 获取所有Pod(从目标集群的informer缓存读取,?cluster=指定目标集群)
  */
 func getPods(c *gin.Context) {
-	pods, err := clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	entry, ok := resolveCluster(c)
+	if !ok {
 		return
 	}
 
@@ -300,16 +419,18 @@ func getPods(c *gin.Context) {
 		Images    []string `json:"images"`
 	}
 
+	user := userFromContext(c)
+
 	var podInfos []PodInfo
-	for _, pod := range pods.Items {
+	for _, item := range entry.podCache.List("") {
+		pod := item.(*v1.Pod)
+		if !isNamespaceAllowed(user, pod.Namespace) {
+			continue
+		}
 		var images []string
 		for _, container := range pod.Spec.Containers {
 			images = append(images, container.Image)
 		}
-		if pod.Namespace == "kube-system" || pod.Namespace == "kube-public" ||
-			pod.Namespace == "kube-node-lease" || pod.Namespace == "kubernetes-dashboard" {
-			continue
-		}
 		podInfos = append(podInfos, PodInfo{
 			Name:      pod.Name,
 			Namespace: pod.Namespace,
@@ -323,21 +444,15 @@ func getPods(c *gin.Context) {
 }
 
 /**
- * 根据JSON创建Deployment
+ * 根据JSON创建Deployment(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func createDeployment(c *gin.Context) {
-	var deployment appsv1.Deployment
-	//if err := c.ShouldBindJSON(&deployment); err != nil {
-	//	if err := c.ShouldBindYAML(&deployment); err != nil {
-	//		if err := c.ShouldBindBodyWithJSON(&deployment); err != nil {
-	//			if err := c.ShouldBindBodyWithYAML(&deployment); err != nil {
-	//				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	//				return
-	//			}
-	//		}
-	//	}
-	//}
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
 
+	var deployment appsv1.Deployment
 	contentType := c.Request.Header.Get("Content-Type")
 	switch contentType {
 	case "application/json":
@@ -359,8 +474,23 @@ func createDeployment(c *gin.Context) {
 		deployment.Namespace = "default"
 	}
 
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, deployment.Namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordClientsetCall("deployments", "create")
 	createdDeployment, err := clientset.AppsV1().Deployments(deployment.Namespace).Create(context.TODO(), &deployment, metav1.CreateOptions{})
 	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "create", "deployments", deployment.Namespace)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -369,28 +499,46 @@ func createDeployment(c *gin.Context) {
 }
 
 /**
- * 根据Namespace和Deployment名称删除Deployment
+ * 根据Namespace和Deployment名称删除Deployment(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func deleteDeployment(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	err := clientset.AppsV1().Deployments(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	recordClientsetCall("deployments", "delete")
+	if err := clientset.AppsV1().Deployments(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "delete", "deployments", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
 
 /**
- * 获取所有Deployment
+ * 获取所有Deployment(从目标集群的informer缓存读取,?cluster=指定目标集群)
  */
 func getDeployments(c *gin.Context) {
-	deployments, err := clientset.AppsV1().Deployments("").List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	entry, ok := resolveCluster(c)
+	if !ok {
 		return
 	}
 
@@ -403,16 +551,18 @@ func getDeployments(c *gin.Context) {
 		Images            []string `json:"images"`
 	}
 
+	user := userFromContext(c)
+
 	var deploymentInfos []DeploymentInfo
-	for _, deployment := range deployments.Items {
+	for _, item := range entry.deploymentCache.List("") {
+		deployment := item.(*appsv1.Deployment)
+		if !isNamespaceAllowed(user, deployment.Namespace) {
+			continue
+		}
 		var images []string
 		for _, container := range deployment.Spec.Template.Spec.Containers {
 			images = append(images, container.Image)
 		}
-		if deployment.Namespace == "kube-system" || deployment.Namespace == "kube-public" ||
-			deployment.Namespace == "kube-node-lease" || deployment.Namespace == "kubernetes-dashboard" {
-			continue
-		}
 		deploymentInfos = append(deploymentInfos, DeploymentInfo{
 			Name:              deployment.Name,
 			Namespace:         deployment.Namespace,
@@ -426,21 +576,15 @@ func getDeployments(c *gin.Context) {
 }
 
 /**
- * 根据JSON创建Service
+ * 根据JSON创建Service(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func createService(c *gin.Context) {
-	var service v1.Service
-	//if err := c.ShouldBindJSON(&service); err != nil {
-	//	if err := c.ShouldBindYAML(&service); err != nil {
-	//		if err := c.ShouldBindBodyWithJSON(&service); err != nil {
-	//			if err := c.ShouldBindBodyWithYAML(&service); err != nil {
-	//				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	//				return
-	//			}
-	//		}
-	//	}
-	//}
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
 
+	var service v1.Service
 	contentType := c.Request.Header.Get("Content-Type")
 	switch contentType {
 	case "application/json":
@@ -460,11 +604,25 @@ func createService(c *gin.Context) {
 
 	if service.Namespace == "" {
 		service.Namespace = "default"
+	}
 
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, service.Namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
+	recordClientsetCall("services", "create")
 	createdService, err := clientset.CoreV1().Services(service.Namespace).Create(context.TODO(), &service, metav1.CreateOptions{})
 	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "create", "services", service.Namespace)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -473,28 +631,46 @@ func createService(c *gin.Context) {
 }
 
 /**
- * 根据Namespace和Service名称删除Service
+ * 根据Namespace和Service名称删除Service(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func deleteService(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	err := clientset.CoreV1().Services(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	recordClientsetCall("services", "delete")
+	if err := clientset.CoreV1().Services(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "delete", "services", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
 
 /**
- * 获取所有Service
+ * 获取所有Service(从目标集群的informer缓存读取,?cluster=指定目标集群)
  */
 func getServices(c *gin.Context) {
-	services, err := clientset.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	entry, ok := resolveCluster(c)
+	if !ok {
 		return
 	}
 
@@ -507,16 +683,18 @@ func getServices(c *gin.Context) {
 		Ports     []string `json:"ports"`
 	}
 
+	user := userFromContext(c)
+
 	var serviceInfos []ServiceInfo
-	for _, service := range services.Items {
+	for _, item := range entry.serviceCache.List("") {
+		service := item.(*v1.Service)
+		if !isNamespaceAllowed(user, service.Namespace) {
+			continue
+		}
 		var ports []string
 		for _, port := range service.Spec.Ports {
 			ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
 		}
-		if service.Namespace == "kube-system" || service.Namespace == "kube-public" ||
-			service.Namespace == "kube-node-lease" || service.Namespace == "kubernetes-dashboard" {
-			continue
-		}
 		serviceInfos = append(serviceInfos, ServiceInfo{
 			Name:      service.Name,
 			Namespace: service.Namespace,
@@ -530,21 +708,15 @@ func getServices(c *gin.Context) {
 }
 
 /**
- * 根据JSON创建ConfigMap
+ * 根据JSON创建ConfigMap(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func createConfigMap(c *gin.Context) {
-	var configMap v1.ConfigMap
-	//if err := c.ShouldBindJSON(&configMap); err != nil {
-	//	if err := c.ShouldBindYAML(&configMap); err != nil {
-	//		if err := c.ShouldBindBodyWithJSON(&configMap); err != nil {
-	//			if err := c.ShouldBindBodyWithYAML(&configMap); err != nil {
-	//				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	//				return
-	//			}
-	//		}
-	//	}
-	//}
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
 
+	var configMap v1.ConfigMap
 	contentType := c.Request.Header.Get("Content-Type")
 	switch contentType {
 	case "application/json":
@@ -566,8 +738,23 @@ func createConfigMap(c *gin.Context) {
 		configMap.Namespace = "default"
 	}
 
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, configMap.Namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordClientsetCall("configmaps", "create")
 	createdConfigMap, err := clientset.CoreV1().ConfigMaps(configMap.Namespace).Create(context.TODO(), &configMap, metav1.CreateOptions{})
 	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "create", "configmaps", configMap.Namespace)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -576,28 +763,46 @@ func createConfigMap(c *gin.Context) {
 }
 
 /**
- * 根据Namespace和ConfigMap名称删除ConfigMap
+ * 根据Namespace和ConfigMap名称删除ConfigMap(?cluster=指定目标集群,默认为defaultClusterName)
  */
 func deleteConfigMap(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	err := clientset.CoreV1().ConfigMaps(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	recordClientsetCall("configmaps", "delete")
+	if err := clientset.CoreV1().ConfigMaps(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "delete", "configmaps", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
 
 /**
- * 获取所有ConfigMap
+ * 获取所有ConfigMap(从目标集群的informer缓存读取,?cluster=指定目标集群)
  */
 func getConfigMaps(c *gin.Context) {
-	configMaps, err := clientset.CoreV1().ConfigMaps("").List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	entry, ok := resolveCluster(c)
+	if !ok {
 		return
 	}
 
@@ -608,16 +813,18 @@ func getConfigMaps(c *gin.Context) {
 		Keys      []string `json:"keys"`
 	}
 
+	user := userFromContext(c)
+
 	var configMapInfos []ConfigMapInfo
-	for _, configMap := range configMaps.Items {
+	for _, item := range entry.configMapCache.List("") {
+		configMap := item.(*v1.ConfigMap)
+		if !isNamespaceAllowed(user, configMap.Namespace) {
+			continue
+		}
 		var keys []string
 		for key := range configMap.Data {
 			keys = append(keys, key)
 		}
-		if configMap.Namespace == "kube-system" || configMap.Namespace == "kube-public" ||
-			configMap.Namespace == "kube-node-lease" || configMap.Namespace == "kubernetes-dashboard" {
-			continue
-		}
 		configMapInfos = append(configMapInfos, ConfigMapInfo{
 			Name:      configMap.Name,
 			Namespace: configMap.Namespace,
@@ -627,3 +834,47 @@ func getConfigMaps(c *gin.Context) {
 
 	c.JSON(http.StatusOK, configMapInfos)
 }
+
+/**
+ * SSE端点,按资源类型(pods/deployments/services/configmaps)推送Added/Updated/Deleted事件,
+ * 前端可以借此实现无需轮询的实时看板(?cluster=指定目标集群)。
+ */
+func watchResource(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	resource := c.Param("resource")
+	switch resource {
+	case "pods", "deployments", "services", "configmaps":
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown resource type: " + resource})
+		return
+	}
+
+	user := userFromContext(c)
+
+	ch := entry.watchHub.Subscribe(resource)
+	defer entry.watchHub.Unsubscribe(resource, ch)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return false
+			}
+			if accessor, ok := event.Object.(metav1.Object); ok && !isNamespaceAllowed(user, accessor.GetNamespace()) {
+				return true
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// websocket数据帧的第一个字节表示流序号,与kubectl attach/exec保持一致的约定,
+// 这样浏览器端的xterm.js可以直接按这个协议解析,无需额外的消息信封。
+const (
+	streamStdin  byte = 0
+	streamStdout byte = 1
+	streamStderr byte = 2
+	streamError  byte = 3
+	streamResize byte = 4
+)
+
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+/**
+ * 流式返回容器日志,支持?container=&follow=true&tailLines=(GET /pod/:namespace/:name/logs)
+ */
+func streamPodLogs(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := &v1.PodLogOptions{
+		Container: c.Query("container"),
+		Follow:    c.Query("follow") == "true",
+	}
+	if tailLines := c.Query("tailLines"); tailLines != "" {
+		if n, err := strconv.ParseInt(tailLines, 10, 64); err == nil {
+			opts.TailLines = &n
+		}
+	}
+
+	req := clientset.CoreV1().Pods(namespace).GetLogs(name, opts)
+	logStream, err := req.Stream(context.TODO())
+	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			respondForbidden(c, entry, user, "get", "pods/log", namespace)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer logStream.Close()
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := logStream.Read(buf)
+		if n > 0 {
+			if _, werr := c.Writer.Write(buf[:n]); werr != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("error streaming logs for %s/%s: %v", namespace, name, err)
+			}
+			return
+		}
+	}
+}
+
+/**
+ * 升级为websocket并把stdin/stdout/stderr代理到SPDYExecutor,提供交互式shell(GET /pod/:namespace/:name/exec)
+ */
+func execPod(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	container := c.Query("container")
+	command := c.QueryArray("command")
+	if len(command) == 0 {
+		command = []string{"/bin/sh"}
+	}
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	config := restConfigForUser(entry, user)
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(name).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		writeWSError(conn, err)
+		return
+	}
+
+	handler := newWSTerminal(conn)
+
+	err = executor.StreamWithContext(c.Request.Context(), remotecommand.StreamOptions{
+		Stdin:             handler,
+		Stdout:            handler.stdoutWriter(),
+		Stderr:            handler.stderrWriter(),
+		Tty:               true,
+		TerminalSizeQueue: handler,
+	})
+	if err != nil {
+		writeWSError(conn, err)
+	}
+}
+
+/**
+ * 把Pod的一个TCP端口转发到后端所在主机的localhost同名端口(GET /pod/:namespace/:name/portforward?port=)。
+ * 数据不经过websocket传输;websocket只接收portforward的状态输出和错误帧,连接断开时转发随之停止。
+ */
+func portForwardPod(c *gin.Context) {
+	entry, ok := resolveCluster(c)
+	if !ok {
+		return
+	}
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	port := c.Query("port")
+	if port == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "port is required"})
+		return
+	}
+
+	user := userFromContext(c)
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+	clientset, err := clientsetForUser(entry, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	config := restConfigForUser(entry, user)
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(name).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		writeWSError(conn, err)
+		return
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+
+	readyChan := make(chan struct{})
+	stopChan := make(chan struct{})
+
+	wsConnAdapter := &wsPipe{conn: conn}
+	fw, err := portforward.NewOnAddresses(dialer, []string{"localhost"}, []string{port}, stopChan, readyChan, wsConnAdapter, wsConnAdapter)
+	if err != nil {
+		writeWSError(conn, err)
+		return
+	}
+
+	go func() {
+		<-c.Request.Context().Done()
+		close(stopChan)
+	}()
+
+	if err := fw.ForwardPorts(); err != nil {
+		writeWSError(conn, err)
+	}
+}
+
+func writeWSError(conn *websocket.Conn, err error) {
+	_ = conn.WriteMessage(websocket.BinaryMessage, append([]byte{streamError}, []byte(err.Error())...))
+}
+
+// wsTerminal把单条websocket连接按kubectl的"首字节=流序号"约定,
+// 拆分成remotecommand需要的stdin Reader以及stdout/stderr Writer,并承载resize事件。
+type wsTerminal struct {
+	conn     *websocket.Conn
+	writeMu  sync.Mutex
+	sizeChan chan remotecommand.TerminalSize
+}
+
+func newWSTerminal(conn *websocket.Conn) *wsTerminal {
+	return &wsTerminal{
+		conn:     conn,
+		sizeChan: make(chan remotecommand.TerminalSize, 1),
+	}
+}
+
+func (t *wsTerminal) Read(p []byte) (int, error) {
+	for {
+		_, message, err := t.conn.ReadMessage()
+		if err != nil {
+			return 0, err
+		}
+		if len(message) == 0 {
+			continue
+		}
+		switch message[0] {
+		case streamStdin:
+			return copy(p, message[1:]), nil
+		case streamResize:
+			size, ok := parseTerminalSize(message[1:])
+			if ok {
+				select {
+				case t.sizeChan <- size:
+				default:
+				}
+			}
+		}
+	}
+}
+
+func (t *wsTerminal) Next() *remotecommand.TerminalSize {
+	size, ok := <-t.sizeChan
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+func (t *wsTerminal) write(channel byte, p []byte) (int, error) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if err := t.conn.WriteMessage(websocket.BinaryMessage, append([]byte{channel}, p...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *wsTerminal) stdoutWriter() io.Writer {
+	return channelWriter{t: t, channel: streamStdout}
+}
+
+func (t *wsTerminal) stderrWriter() io.Writer {
+	return channelWriter{t: t, channel: streamStderr}
+}
+
+type channelWriter struct {
+	t       *wsTerminal
+	channel byte
+}
+
+func (w channelWriter) Write(p []byte) (int, error) {
+	return w.t.write(w.channel, p)
+}
+
+// wsPipe把websocket连接适配成portforward.NewOnAddresses需要的io.Writer(用于stdout/stderr日志),
+// 真正的数据隧道由portforward库内部通过SPDY stream与dataStream之间拷贝完成。
+type wsPipe struct {
+	conn *websocket.Conn
+}
+
+func (p *wsPipe) Write(b []byte) (int, error) {
+	if err := p.conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func parseTerminalSize(b []byte) (remotecommand.TerminalSize, bool) {
+	// 约定resize负载为4字节: 高2字节=cols, 低2字节=rows
+	if len(b) != 4 {
+		return remotecommand.TerminalSize{}, false
+	}
+	cols := uint16(b[0])<<8 | uint16(b[1])
+	rows := uint16(b[2])<<8 | uint16(b[3])
+	return remotecommand.TerminalSize{Width: cols, Height: rows}, true
+}
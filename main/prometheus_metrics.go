@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// promLabelValuePattern只允许k8s资源名/DNS子域名会出现的字符,
+// name/namespace这类路径参数在拼进PromQL标签匹配器之前必须先过这一关,
+// 否则"\""可以闭合掉标签值让调用方注入任意PromQL(读取其他命名空间的序列、发起高开销查询)。
+var promLabelValuePattern = regexp.MustCompile(`^[a-zA-Z0-9.-]+$`)
+
+func isValidPromLabelValue(s string) bool {
+	return s != "" && promLabelValuePattern.MatchString(s)
+}
+
+// promQueryAPI是到PROMETHEUS_URL的只读查询客户端,为空表示未配置,
+// /metrics/node和/metrics/pod这两个历史数据端点在这种情况下直接返回501。
+var promQueryAPI promv1.API
+
+// initPrometheusClient按PROMETHEUS_URL环境变量构建Prometheus HTTP API客户端。
+// 环境变量未设置时保持promQueryAPI为nil,历史指标端点会在调用时报告未配置。
+func initPrometheusClient() error {
+	url := os.Getenv("PROMETHEUS_URL")
+	if url == "" {
+		return nil
+	}
+
+	client, err := promapi.NewClient(promapi.Config{Address: url})
+	if err != nil {
+		return fmt.Errorf("failed to create prometheus client: %w", err)
+	}
+	promQueryAPI = promv1.NewAPI(client)
+	return nil
+}
+
+// parseRangeQuery把?range=1h&step=30s解析成Prometheus的v1.Range,range默认1小时,step默认30秒。
+func parseRangeQuery(c *gin.Context) (promv1.Range, error) {
+	rangeStr := c.DefaultQuery("range", "1h")
+	stepStr := c.DefaultQuery("step", "30s")
+
+	duration, err := time.ParseDuration(rangeStr)
+	if err != nil {
+		return promv1.Range{}, fmt.Errorf("invalid range: %w", err)
+	}
+	step, err := time.ParseDuration(stepStr)
+	if err != nil {
+		return promv1.Range{}, fmt.Errorf("invalid step: %w", err)
+	}
+
+	now := time.Now()
+	return promv1.Range{Start: now.Add(-duration), End: now, Step: step}, nil
+}
+
+/**
+ * 查询某个节点的CPU/内存历史用量(GET /metrics/node/:name?range=1h&step=30s)
+ */
+func getNodeRangeMetrics(c *gin.Context) {
+	if promQueryAPI == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "PROMETHEUS_URL is not configured"})
+		return
+	}
+
+	name := c.Param("name")
+	if !isValidPromLabelValue(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid node name"})
+		return
+	}
+
+	r, err := parseRangeQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cpuQuery := fmt.Sprintf(`sum(rate(node_cpu_seconds_total{instance=~"%s.*",mode!="idle"}[2m])) by (mode)`, name)
+	memQuery := fmt.Sprintf(`node_memory_MemTotal_bytes{instance=~"%s.*"} - node_memory_MemAvailable_bytes{instance=~"%s.*"}`, name, name)
+
+	cpuSeries, err := queryRange(c.Request.Context(), cpuQuery, r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	memSeries, err := queryRange(c.Request.Context(), memQuery, r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cpu":    cpuSeries,
+		"memory": memSeries,
+	})
+}
+
+/**
+ * 查询某个Pod按容器拆分的CPU/内存历史用量(GET /metrics/pod/:ns/:name?range=1h&step=30s)
+ */
+func getPodRangeMetrics(c *gin.Context) {
+	if promQueryAPI == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "PROMETHEUS_URL is not configured"})
+		return
+	}
+
+	namespace := c.Param("ns")
+	name := c.Param("name")
+	if !isValidPromLabelValue(namespace) || !isValidPromLabelValue(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid namespace or pod name"})
+		return
+	}
+
+	if !requireNamespaceAccess(c, userFromContext(c), namespace) {
+		return
+	}
+
+	r, err := parseRangeQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cpuQuery := fmt.Sprintf(
+		`sum(rate(container_cpu_usage_seconds_total{namespace="%s",pod="%s"}[2m])) by (container)`,
+		namespace, name)
+	memQuery := fmt.Sprintf(
+		`container_memory_working_set_bytes{namespace="%s",pod="%s"}`,
+		namespace, name)
+
+	cpuSeries, err := queryRange(c.Request.Context(), cpuQuery, r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	memSeries, err := queryRange(c.Request.Context(), memQuery, r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cpu":    cpuSeries,
+		"memory": memSeries,
+	})
+}
+
+// seriesPoint是时间序列里的一个采样点,time为unix秒,便于前端图表库直接消费。
+type seriesPoint struct {
+	Time  float64 `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// labeledSeries是一条带标签的时间序列,例如按container或mode拆分出来的曲线。
+type labeledSeries struct {
+	Labels model.Metric  `json:"labels"`
+	Points []seriesPoint `json:"points"`
+}
+
+func queryRange(ctx context.Context, query string, r promv1.Range) ([]labeledSeries, error) {
+	value, warnings, err := promQueryAPI.QueryRange(ctx, query, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(warnings) > 0 {
+		// Prometheus返回的warnings不影响结果的可用性,忽略即可。
+		_ = warnings
+	}
+
+	matrix, ok := value.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("unexpected prometheus result type: %T", value)
+	}
+
+	series := make([]labeledSeries, 0, len(matrix))
+	for _, stream := range matrix {
+		points := make([]seriesPoint, 0, len(stream.Values))
+		for _, sample := range stream.Values {
+			points = append(points, seriesPoint{
+				Time:  float64(sample.Timestamp.Unix()),
+				Value: float64(sample.Value),
+			})
+		}
+		series = append(series, labeledSeries{Labels: stream.Metric, Points: points})
+	}
+
+	return series, nil
+}
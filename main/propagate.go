@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type propagateRequest struct {
+	Clusters []string               `json:"clusters" binding:"required"`
+	Manifest map[string]interface{} `json:"manifest" binding:"required"`
+}
+
+type propagateResult struct {
+	Success bool        `json:"success"`
+	Object  interface{} `json:"object,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+/**
+ * 把一个workload下发到多个集群,返回每个集群各自的成功/失败结果(POST /propagate)
+ */
+func propagateWorkload(c *gin.Context) {
+	var req propagateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := userFromContext(c)
+	namespace := manifestNamespace(&unstructured.Unstructured{Object: req.Manifest})
+	if !requireNamespaceAccess(c, user, namespace) {
+		return
+	}
+
+	results := make(map[string]propagateResult, len(req.Clusters))
+	for _, clusterName := range req.Clusters {
+		entry, err := clusterManager.Get(clusterName)
+		if err != nil {
+			results[clusterName] = propagateResult{Success: false, Error: err.Error()}
+			continue
+		}
+
+		obj := &unstructured.Unstructured{Object: deepCopyManifest(req.Manifest)}
+
+		applied, err := applyToCluster(entry, user, obj)
+		if err != nil {
+			results[clusterName] = propagateResult{Success: false, Error: err.Error()}
+			continue
+		}
+		results[clusterName] = propagateResult{Success: true, Object: applied}
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// deepCopyManifest通过JSON往返做一次深拷贝,避免多个集群的apply调用互相污染同一份map。
+func deepCopyManifest(manifest map[string]interface{}) map[string]interface{} {
+	raw, err := json.Marshal(manifest)
+	if err != nil {
+		return manifest
+	}
+	var copied map[string]interface{}
+	if err := json.Unmarshal(raw, &copied); err != nil {
+		return manifest
+	}
+	return copied
+}
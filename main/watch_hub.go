@@ -0,0 +1,69 @@
+package main
+
+import "sync"
+
+// WatchEvent 是推送给 SSE 订阅者的事件，Type 取值为 Added/Updated/Deleted。
+type WatchEvent struct {
+	Type     string      `json:"type"`
+	Resource string      `json:"resource"`
+	Object   interface{} `json:"object"`
+}
+
+// WatchHub 按资源类型(pods/deployments/services/configmaps)维护订阅者列表，
+// informer 的事件回调通过 Publish 广播给所有连接的 SSE 客户端。
+type WatchHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan WatchEvent]struct{}
+}
+
+func NewWatchHub() *WatchHub {
+	return &WatchHub{
+		subscribers: make(map[string]map[chan WatchEvent]struct{}),
+	}
+}
+
+func (h *WatchHub) Subscribe(resource string) chan WatchEvent {
+	ch := make(chan WatchEvent, 16)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[resource] == nil {
+		h.subscribers[resource] = make(map[chan WatchEvent]struct{})
+	}
+	h.subscribers[resource][ch] = struct{}{}
+
+	return ch
+}
+
+func (h *WatchHub) Unsubscribe(resource string, ch chan WatchEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if subs, ok := h.subscribers[resource]; ok {
+		delete(subs, ch)
+	}
+	close(ch)
+}
+
+func (h *WatchHub) Publish(resource string, event WatchEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[resource] {
+		select {
+		case ch <- event:
+		default:
+			// 订阅者消费不及时，丢弃这条事件而不是阻塞informer的回调
+		}
+	}
+}
+
+// Close 关闭所有订阅者的channel，在服务优雅关闭时调用。
+func (h *WatchHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for resource, subs := range h.subscribers {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(h.subscribers, resource)
+	}
+}